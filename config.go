@@ -0,0 +1,282 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SSLConfig holds the TLS settings for a single database connection. Postgres
+// accepts these as either libpq connection parameters or URI query
+// parameters; we always emit them as the latter.
+type SSLConfig struct {
+	Mode     string `yaml:"sslmode"`
+	RootCert string `yaml:"sslrootcert"`
+	Cert     string `yaml:"sslcert"`
+	Key      string `yaml:"sslkey"`
+}
+
+// DBConfig holds everything needed to dial one side (source or destination)
+// of a migration.
+type DBConfig struct {
+	Host     string    `yaml:"host"`
+	Port     string    `yaml:"port"`
+	User     string    `yaml:"user"`
+	Password string    `yaml:"password"`
+	DBName   string    `yaml:"dbname"`
+	SSL      SSLConfig `yaml:"ssl"`
+}
+
+// Config is the fully resolved configuration for a migration run.
+type Config struct {
+	Source      DBConfig
+	Destination DBConfig
+
+	// ParallelTables bounds how many tables are dumped and restored
+	// concurrently. It is independent of pg_dump/pg_restore's own --jobs,
+	// which parallelizes within a single table's dump.
+	ParallelTables int
+
+	// RunSchemaMigrations, when set, runs `schema up` against the
+	// destination before restoring data.
+	RunSchemaMigrations bool
+	MigrationsDir       string
+
+	// AllowSchemaDrift skips aborting the migration when the pre-flight
+	// schema diff finds differences between source and destination.
+	AllowSchemaDrift bool
+
+	// ReportPath, if set, writes the post-restore verification report as
+	// JSON to this path in addition to logging it.
+	ReportPath string
+
+	// SyncSequences calls setval on the destination for any sequence whose
+	// current value doesn't match the source, during post-restore
+	// verification.
+	SyncSequences bool
+
+	// AutoRecreateDestination drops and recreates the destination database
+	// when a restore fails with a recoverable error, then retries once.
+	AutoRecreateDestination bool
+
+	// AssumeYes skips the interactive confirmation prompt before a
+	// destructive auto-recreate.
+	AssumeYes bool
+}
+
+// fileConfig mirrors Config but with yaml tags; it is the on-disk shape of
+// the --config file.
+type fileConfig struct {
+	Source         DBConfig `yaml:"source"`
+	Destination    DBConfig `yaml:"destination"`
+	ParallelTables int      `yaml:"parallel_tables"`
+}
+
+// URI builds a postgres connection URI for c, percent-escaping the user and
+// password the way pkgsite's DBConnURI does so that special characters
+// (":", "@", "/", etc.) in credentials can't corrupt the URI.
+func (c DBConfig) URI() string {
+	u := &url.URL{
+		Scheme: "postgres",
+		Host:   fmt.Sprintf("%s:%s", c.Host, c.Port),
+		Path:   "/" + c.DBName,
+	}
+	if c.User != "" {
+		if c.Password != "" {
+			u.User = url.UserPassword(c.User, c.Password)
+		} else {
+			u.User = url.User(c.User)
+		}
+	}
+
+	q := u.Query()
+	if c.SSL.Mode != "" {
+		q.Set("sslmode", c.SSL.Mode)
+	}
+	if c.SSL.RootCert != "" {
+		q.Set("sslrootcert", c.SSL.RootCert)
+	}
+	if c.SSL.Cert != "" {
+		q.Set("sslcert", c.SSL.Cert)
+	}
+	if c.SSL.Key != "" {
+		q.Set("sslkey", c.SSL.Key)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// loadConfig resolves a Config from (in increasing priority) a YAML config
+// file, environment variables, and CLI flags.
+func loadConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("db-migration", flag.ExitOnError)
+
+	configPath := fs.String("config", "", "path to a YAML config file")
+	parallelTables := fs.Int("parallel-tables", 0, "number of tables to dump/restore concurrently")
+	runSchemaMigrations := fs.Bool("with-schema-migrations", false, "run `schema up` against the destination before restoring data")
+	migrationsDir := fs.String("migrations-dir", "./migrations", "directory of golang-migrate schema migration files")
+	allowSchemaDrift := fs.Bool("allow-schema-drift", false, "proceed with the migration even if the pre-flight schema check finds differences")
+	reportPath := fs.String("report", "", "write the post-restore verification report as JSON to this path")
+	syncSequences := fs.Bool("sync-sequences", false, "sync destination sequence values to the source during verification")
+	autoRecreateDestination := fs.Bool("auto-recreate-destination", false, "drop and recreate the destination database and retry once if restore fails with a recoverable error")
+	assumeYes := fs.Bool("yes", false, "skip the confirmation prompt before a destructive auto-recreate")
+
+	srcHost := fs.String("source-host", "", "source database host")
+	srcPort := fs.String("source-port", "", "source database port")
+	srcUser := fs.String("source-user", "", "source database user")
+	srcPassword := fs.String("source-password", "", "source database password")
+	srcDBName := fs.String("source-dbname", "", "source database name")
+	srcSSLMode := fs.String("source-sslmode", "", "source sslmode (disable/require/verify-ca/verify-full)")
+	srcSSLRootCert := fs.String("source-sslrootcert", "", "source CA certificate path")
+	srcSSLCert := fs.String("source-sslcert", "", "source client certificate path")
+	srcSSLKey := fs.String("source-sslkey", "", "source client key path")
+
+	dstHost := fs.String("dest-host", "", "destination database host")
+	dstPort := fs.String("dest-port", "", "destination database port")
+	dstUser := fs.String("dest-user", "", "destination database user")
+	dstPassword := fs.String("dest-password", "", "destination database password")
+	dstDBName := fs.String("dest-dbname", "", "destination database name")
+	dstSSLMode := fs.String("dest-sslmode", "", "destination sslmode (disable/require/verify-ca/verify-full)")
+	dstSSLRootCert := fs.String("dest-sslrootcert", "", "destination CA certificate path")
+	dstSSLCert := fs.String("dest-sslcert", "", "destination client certificate path")
+	dstSSLKey := fs.String("dest-sslkey", "", "destination client key path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{ParallelTables: 4, MigrationsDir: "./migrations"}
+
+	// Lowest priority: config file.
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath, cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config file %q: %w", *configPath, err)
+		}
+	}
+
+	// Next: environment variables.
+	applyEnv(&cfg.Source, "MIGRATE_SOURCE")
+	applyEnv(&cfg.Destination, "MIGRATE_DEST")
+	if v := os.Getenv("MIGRATE_PARALLEL_TABLES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ParallelTables = n
+		}
+	}
+
+	// Highest priority: CLI flags.
+	applyFlags(&cfg.Source, srcHost, srcPort, srcUser, srcPassword, srcDBName, srcSSLMode, srcSSLRootCert, srcSSLCert, srcSSLKey)
+	applyFlags(&cfg.Destination, dstHost, dstPort, dstUser, dstPassword, dstDBName, dstSSLMode, dstSSLRootCert, dstSSLCert, dstSSLKey)
+	if *parallelTables != 0 {
+		cfg.ParallelTables = *parallelTables
+	}
+	if *runSchemaMigrations {
+		cfg.RunSchemaMigrations = true
+	}
+	if *migrationsDir != "" {
+		cfg.MigrationsDir = *migrationsDir
+	}
+	if *allowSchemaDrift {
+		cfg.AllowSchemaDrift = true
+	}
+	if *reportPath != "" {
+		cfg.ReportPath = *reportPath
+	}
+	if *syncSequences {
+		cfg.SyncSequences = true
+	}
+	if *autoRecreateDestination {
+		cfg.AutoRecreateDestination = true
+	}
+	if *assumeYes {
+		cfg.AssumeYes = true
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fc fileConfig
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	cfg.Source = fc.Source
+	cfg.Destination = fc.Destination
+	if fc.ParallelTables != 0 {
+		cfg.ParallelTables = fc.ParallelTables
+	}
+	return nil
+}
+
+// applyEnv overlays db.* fields with MIGRATE_<prefix>_* environment
+// variables when they are set.
+func applyEnv(db *DBConfig, prefix string) {
+	set := func(dst *string, suffix string) {
+		if v := os.Getenv(prefix + "_" + suffix); v != "" {
+			*dst = v
+		}
+	}
+	set(&db.Host, "HOST")
+	set(&db.Port, "PORT")
+	set(&db.User, "USER")
+	set(&db.Password, "PASSWORD")
+	set(&db.DBName, "DBNAME")
+	set(&db.SSL.Mode, "SSLMODE")
+	set(&db.SSL.RootCert, "SSLROOTCERT")
+	set(&db.SSL.Cert, "SSLCERT")
+	set(&db.SSL.Key, "SSLKEY")
+}
+
+// applyFlags overlays db's fields with any flags the user actually passed.
+func applyFlags(db *DBConfig, host, port, user, password, dbname, sslMode, sslRootCert, sslCert, sslKey *string) {
+	set := func(dst *string, src *string) {
+		if *src != "" {
+			*dst = *src
+		}
+	}
+	set(&db.Host, host)
+	set(&db.Port, port)
+	set(&db.User, user)
+	set(&db.Password, password)
+	set(&db.DBName, dbname)
+	set(&db.SSL.Mode, sslMode)
+	set(&db.SSL.RootCert, sslRootCert)
+	set(&db.SSL.Cert, sslCert)
+	set(&db.SSL.Key, sslKey)
+}
+
+func (c *Config) validate() error {
+	sides := []struct {
+		name   string
+		db     DBConfig
+		envTag string
+	}{
+		{"source", c.Source, "MIGRATE_SOURCE"},
+		{"destination", c.Destination, "MIGRATE_DEST"},
+	}
+	for _, side := range sides {
+		if side.db.Host == "" || side.db.DBName == "" {
+			return fmt.Errorf("%s: host and dbname are required (via flags, %s_* env vars, or --config)", side.name, side.envTag)
+		}
+		switch side.db.SSL.Mode {
+		case "", "disable", "require", "verify-ca", "verify-full":
+		default:
+			return fmt.Errorf("%s: invalid sslmode %q", side.name, side.db.SSL.Mode)
+		}
+	}
+	return nil
+}
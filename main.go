@@ -5,19 +5,24 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 
 	_ "github.com/lib/pq"
 )
 
-const (
-	sourceDB      = "your_source_db_connection_string"
-	destinationDB = "your_destination_db_connection_string"
-	jobs          = "4" // Number of parallel jobs for performance
-)
-
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		if err := runSchemaCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ Schema migration failed: %v", err)
+		}
+		return
+	}
+
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ Invalid configuration: %v", err)
+	}
+
 	// Get working directory for backups
 	workingDir, err := os.Getwd()
 	if err != nil {
@@ -28,7 +33,7 @@ func main() {
 	log.Printf("Using %s as the backup directory...\n", dumpDir)
 
 	// ✅ Step 1: Test Database Connections
-	if !testDBConnection(sourceDB, "Source") || !testDBConnection(destinationDB, "Destination") {
+	if !testDBConnection(cfg.Source.URI(), "Source") || !testDBConnection(cfg.Destination.URI(), "Destination") {
 		log.Fatal("❌ Database connection test failed. Migration aborted.")
 	}
 
@@ -37,14 +42,44 @@ func main() {
 		log.Fatalf("❌ Failed to reset backup directory: %v", err)
 	}
 
-	// ✅ Step 3: Dump Data
-	if err := dumpData(dumpDir); err != nil {
-		log.Fatalf("❌ Data dump failed: %v", err)
+	// ✅ Step 3: Apply Schema Migrations (optional)
+	//
+	// Runs before the pre-flight check so that, on a fresh destination,
+	// the schema the data will actually restore into exists by the time
+	// it's diffed against the source -- otherwise preflight always
+	// reports the destination as empty/drifted.
+	if cfg.RunSchemaMigrations {
+		if err := runSchemaCommand([]string{
+			"--dest-host=" + cfg.Destination.Host,
+			"--dest-port=" + cfg.Destination.Port,
+			"--dest-user=" + cfg.Destination.User,
+			"--dest-password=" + cfg.Destination.Password,
+			"--dest-dbname=" + cfg.Destination.DBName,
+			"--dest-sslmode=" + cfg.Destination.SSL.Mode,
+			"--migrations-dir=" + cfg.MigrationsDir,
+			"up",
+		}); err != nil {
+			log.Fatalf("❌ Schema migration failed: %v", err)
+		}
 	}
 
-	// ✅ Step 4: Restore Data
-	if err := restoreData(dumpDir); err != nil {
-		log.Fatalf("❌ Data restoration failed: %v", err)
+	// ✅ Step 4: Pre-flight Schema Check
+	if err := runPreflightCheck(cfg, dumpDir, cfg.AllowSchemaDrift); err != nil {
+		log.Fatalf("❌ Pre-flight schema check failed: %v", err)
+	}
+
+	// ✅ Step 5: Migrate Data, Table by Table
+	result, err := migrateWithAutoRecreate(cfg, dumpDir)
+	if err != nil {
+		log.Fatalf("❌ Data migration failed: %v", err)
+	}
+	if result.Recreated {
+		log.Println("♻️ Destination database was dropped and recreated before the successful retry.")
+	}
+
+	// ✅ Step 6: Post-Restore Verification
+	if err := runVerification(cfg, cfg.ReportPath, cfg.SyncSequences); err != nil {
+		log.Fatalf("❌ Post-restore verification failed: %v", err)
 	}
 
 	log.Println("✅ Database data migration completed successfully!")
@@ -79,21 +114,3 @@ func resetBackupDir(dumpDir string) error {
 	log.Println("📁 Creating a new backup directory...")
 	return os.MkdirAll(dumpDir, 0755)
 }
-
-// ✅ Step 3: Dump Data
-func dumpData(dumpDir string) error {
-	log.Println("📦 Dumping data only...")
-	cmd := exec.Command("pg_dump", "--format=directory", "--no-owner", "--no-acl", "--data-only", "--jobs="+jobs, "--dbname="+sourceDB, "--file="+dumpDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-// ✅ Step 4: Restore Data
-func restoreData(dumpDir string) error {
-	log.Println("🛠️ Restoring data...")
-	restoreCmd := exec.Command("pg_restore", "--jobs="+jobs, "--no-owner", "--no-acl", "--data-only", "--dbname="+destinationDB, dumpDir)
-	restoreCmd.Stdout = os.Stdout
-	restoreCmd.Stderr = os.Stderr
-	return restoreCmd.Run()
-}
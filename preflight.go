@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaDiff reports the statements that appear on only one side of a
+// source/destination schema-only dump.
+type SchemaDiff struct {
+	OnlyInSource      []string
+	OnlyInDestination []string
+}
+
+func (d SchemaDiff) empty() bool {
+	return len(d.OnlyInSource) == 0 && len(d.OnlyInDestination) == 0
+}
+
+// String renders the diff as a human-readable report.
+func (d SchemaDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schema drift detected: %d statement(s) only in source, %d only in destination\n",
+		len(d.OnlyInSource), len(d.OnlyInDestination))
+	for _, s := range d.OnlyInSource {
+		fmt.Fprintf(&b, "  - source only:      %s\n", s)
+	}
+	for _, s := range d.OnlyInDestination {
+		fmt.Fprintf(&b, "  + destination only: %s\n", s)
+	}
+	return b.String()
+}
+
+// runPreflightCheck dumps the schema-only definition of both databases,
+// normalizes them, and diffs them. If allowDrift is false, a non-empty diff
+// aborts the migration.
+func runPreflightCheck(cfg *Config, dumpDir string, allowDrift bool) error {
+	log.Println("🔍 Running pre-flight schema check...")
+
+	sourceSchema, err := dumpSchemaOnly(cfg.Source, filepath.Join(dumpDir, "source_schema.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to dump source schema: %w", err)
+	}
+	destSchema, err := dumpSchemaOnly(cfg.Destination, filepath.Join(dumpDir, "destination_schema.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to dump destination schema: %w", err)
+	}
+
+	diff := diffSchemas(normalizeSchema(sourceSchema), normalizeSchema(destSchema))
+	if diff.empty() {
+		log.Println("✅ Source and destination schemas match.")
+		return nil
+	}
+
+	log.Print(diff.String())
+	if allowDrift {
+		log.Println("⚠️ Schema drift found but --allow-schema-drift was passed; continuing anyway.")
+		return nil
+	}
+
+	return fmt.Errorf("schema drift detected between source and destination; pass --allow-schema-drift to proceed anyway:\n%s", diff.String())
+}
+
+// dumpSchemaOnly runs `pg_dump --schema-only --format=plain` for db and
+// returns its contents, writing a copy to destFile for later inspection.
+func dumpSchemaOnly(db DBConfig, destFile string) (string, error) {
+	cmd := exec.Command("pg_dump", "--schema-only", "--format=plain", "--no-owner", "--no-acl", "--dbname="+db.URI())
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(destFile, out, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", destFile, err)
+	}
+
+	return string(out), nil
+}
+
+var (
+	ownerRegexp = regexp.MustCompile(`(?i)^\s*(ALTER\s+\w+.*\s+OWNER\s+TO\s+\S+;|OWNER\s+TO\s+\S+;)\s*$`)
+	setRegexp   = regexp.MustCompile(`(?i)^\s*SET\s+`)
+)
+
+// normalizeSchema strips comments, SET statements, and ownership changes
+// from a pg_dump --schema-only output, then splits the remainder into
+// individual, sorted CREATE/ALTER statements so that two schemas can be
+// diffed independent of dump ordering.
+func normalizeSchema(sqlText string) []string {
+	scanner := bufio.NewScanner(strings.NewReader(sqlText))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var cleaned strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "--"):
+		case setRegexp.MatchString(trimmed):
+		case ownerRegexp.MatchString(trimmed):
+		default:
+			cleaned.WriteString(line)
+			cleaned.WriteByte('\n')
+		}
+	}
+
+	statements := strings.Split(cleaned.String(), ";")
+	var out []string
+	for _, s := range statements {
+		s = strings.TrimSpace(s)
+		s = strings.Join(strings.Fields(s), " ")
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// diffSchemas reports statements present in only one of the two normalized,
+// sorted statement lists.
+func diffSchemas(source, destination []string) SchemaDiff {
+	sourceSet := make(map[string]bool, len(source))
+	for _, s := range source {
+		sourceSet[s] = true
+	}
+	destSet := make(map[string]bool, len(destination))
+	for _, s := range destination {
+		destSet[s] = true
+	}
+
+	var diff SchemaDiff
+	for _, s := range source {
+		if !destSet[s] {
+			diff.OnlyInSource = append(diff.OnlyInSource, s)
+		}
+	}
+	for _, s := range destination {
+		if !sourceSet[s] {
+			diff.OnlyInDestination = append(diff.OnlyInDestination, s)
+		}
+	}
+	return diff
+}
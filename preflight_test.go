@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeSchemaStripsNoiseAndSorts(t *testing.T) {
+	input := `
+-- Comment that should be dropped
+SET statement_timeout = 0;
+SET search_path = public;
+
+CREATE TABLE public.orders (
+    id integer NOT NULL
+);
+
+ALTER TABLE public.orders OWNER TO postgres;
+
+CREATE TABLE public.accounts (
+    id integer NOT NULL
+);
+`
+
+	got := normalizeSchema(input)
+	want := []string{
+		"CREATE TABLE public.accounts ( id integer NOT NULL )",
+		"CREATE TABLE public.orders ( id integer NOT NULL )",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeSchema() = %#v, want %#v", got, want)
+	}
+}
+
+func TestNormalizeSchemaIgnoresDumpOrdering(t *testing.T) {
+	a := normalizeSchema("CREATE TABLE b (id int);\nCREATE TABLE a (id int);\n")
+	b := normalizeSchema("CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n")
+
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("normalizeSchema should be order-independent: %#v != %#v", a, b)
+	}
+}
+
+func TestDiffSchemasReportsOnlyTheDifference(t *testing.T) {
+	source := normalizeSchema("CREATE TABLE a (id int);\nCREATE TABLE shared (id int);\n")
+	destination := normalizeSchema("CREATE TABLE b (id int);\nCREATE TABLE shared (id int);\n")
+
+	diff := diffSchemas(source, destination)
+
+	if diff.empty() {
+		t.Fatal("expected a non-empty diff")
+	}
+	if !reflect.DeepEqual(diff.OnlyInSource, []string{"CREATE TABLE a (id int)"}) {
+		t.Fatalf("OnlyInSource = %#v", diff.OnlyInSource)
+	}
+	if !reflect.DeepEqual(diff.OnlyInDestination, []string{"CREATE TABLE b (id int)"}) {
+		t.Fatalf("OnlyInDestination = %#v", diff.OnlyInDestination)
+	}
+}
+
+func TestDiffSchemasMatchingSchemasAreEmpty(t *testing.T) {
+	source := normalizeSchema("CREATE TABLE a (id int);\n")
+	destination := normalizeSchema("CREATE TABLE a (id int);\n")
+
+	diff := diffSchemas(source, destination)
+	if !diff.empty() {
+		t.Fatalf("expected empty diff for identical schemas, got %+v", diff)
+	}
+}
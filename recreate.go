@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// recoverableRestoreErrors match pg_restore/pg failure messages that
+// indicate the destination database's existing objects are the problem
+// (stale schema left over from a prior run, data violating FKs/uniqueness,
+// or a column/type that no longer matches) rather than a transient,
+// connectivity, or credentials problem -- only the former is worth a
+// destructive rebuild. Scoped to relation/column/type/constraint-level
+// messages specifically so errors like `role "x" does not exist` or
+// `database "x" does not exist` (typos in connection config) never match.
+var recoverableRestoreErrors = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)relation ".*" already exists`),
+	regexp.MustCompile(`(?i)violates foreign key constraint`),
+	regexp.MustCompile(`(?i)violates unique constraint`),
+	regexp.MustCompile(`(?i)relation ".*" does not exist`),
+	regexp.MustCompile(`(?i)column ".*"( of relation ".*")? does not exist`),
+	regexp.MustCompile(`(?i)type ".*" does not exist`),
+}
+
+// isRecoverableRestoreError reports whether err looks like the kind of
+// destination-state problem that DROP DATABASE + CREATE DATABASE can fix.
+func isRecoverableRestoreError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, re := range recoverableRestoreErrors {
+		if re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// destinationRecreateResult signals to the caller whether the destination
+// database was dropped and recreated, so the caller can decide to re-run
+// schema migrations and retry the restore.
+type destinationRecreateResult struct {
+	Recreated bool
+}
+
+// migrateWithAutoRecreate runs migrateTables and, if it fails with a
+// recoverable error and cfg.AutoRecreateDestination is set, drops and
+// recreates the destination database, re-applies schema migrations if
+// configured, and retries the migration once.
+func migrateWithAutoRecreate(cfg *Config, dumpDir string) (destinationRecreateResult, error) {
+	err := migrateTables(cfg, dumpDir, cfg.ParallelTables)
+	if err == nil {
+		return destinationRecreateResult{}, nil
+	}
+
+	if !cfg.AutoRecreateDestination || !isRecoverableRestoreError(err) {
+		return destinationRecreateResult{}, err
+	}
+
+	log.Printf("⚠️ Restore failed with a recoverable error: %v", err)
+	if err := confirmRecreate(cfg.Destination.DBName, cfg.AssumeYes); err != nil {
+		return destinationRecreateResult{}, err
+	}
+
+	if err := recreateDestinationDatabase(cfg.Destination); err != nil {
+		return destinationRecreateResult{}, fmt.Errorf("failed to recreate destination database: %w", err)
+	}
+	result := destinationRecreateResult{Recreated: true}
+
+	if err := resetCheckpointState(dumpDir); err != nil {
+		return result, fmt.Errorf("failed to reset checkpoint state after recreate: %w", err)
+	}
+
+	if cfg.RunSchemaMigrations {
+		if err := runSchemaCommand([]string{
+			"--dest-host=" + cfg.Destination.Host,
+			"--dest-port=" + cfg.Destination.Port,
+			"--dest-user=" + cfg.Destination.User,
+			"--dest-password=" + cfg.Destination.Password,
+			"--dest-dbname=" + cfg.Destination.DBName,
+			"--dest-sslmode=" + cfg.Destination.SSL.Mode,
+			"--migrations-dir=" + cfg.MigrationsDir,
+			"up",
+		}); err != nil {
+			return result, fmt.Errorf("failed to re-apply schema migrations after recreate: %w", err)
+		}
+	}
+
+	if err := migrateTables(cfg, dumpDir, cfg.ParallelTables); err != nil {
+		return result, fmt.Errorf("retry after recreating destination database still failed: %w", err)
+	}
+	return result, nil
+}
+
+func confirmRecreate(dbName string, assumeYes bool) error {
+	if assumeYes {
+		return nil
+	}
+
+	fmt.Printf("This will DROP and recreate destination database %q. Continue? [y/N] ", dbName)
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return fmt.Errorf("destination recreate aborted by user")
+	}
+	return nil
+}
+
+// recreateDestinationDatabase connects to the `postgres` maintenance
+// database on the destination host and drops and recreates db, preserving
+// its original owner, encoding, and locale.
+func recreateDestinationDatabase(db DBConfig) error {
+	maintenance := db
+	maintenance.DBName = "postgres"
+
+	conn, err := sql.Open("postgres", maintenance.URI())
+	if err != nil {
+		return fmt.Errorf("failed to connect to maintenance database: %w", err)
+	}
+	defer conn.Close()
+
+	var owner, encoding, collate, ctype string
+	err = conn.QueryRow(`
+		SELECT pg_catalog.pg_get_userbyid(d.datdba), pg_catalog.pg_encoding_to_char(d.encoding), d.datcollate, d.datctype
+		FROM pg_database d
+		WHERE d.datname = $1
+	`, db.DBName).Scan(&owner, &encoding, &collate, &ctype)
+	if err != nil {
+		return fmt.Errorf("failed to read existing database metadata: %w", err)
+	}
+
+	log.Printf("🗑️ Dropping destination database %q...", db.DBName)
+	if _, err := conn.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %q WITH (FORCE)`, db.DBName)); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+
+	log.Printf("📁 Recreating destination database %q (owner=%s, encoding=%s)...", db.DBName, owner, encoding)
+	createStmt := fmt.Sprintf(
+		`CREATE DATABASE %q WITH OWNER %q ENCODING %q LC_COLLATE %q LC_CTYPE %q TEMPLATE template0`,
+		db.DBName, owner, encoding, collate, ctype,
+	)
+	if _, err := conn.Exec(createStmt); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// historyTable is the custom table schema migrations are logged to, one row
+// per applied (or reverted) version, in addition to golang-migrate's own
+// internal schema_migrations bookkeeping table.
+const historyTable = "schema_migrations_history"
+
+// runSchemaCommand implements the `schema` subcommand: up, down N, goto V,
+// force V, and version, run against the destination database.
+func runSchemaCommand(args []string) error {
+	destCfg, migrationsDir, op, opArgs, err := parseSchemaArgs(args)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", destCfg.URI())
+	if err != nil {
+		return fmt.Errorf("failed to open destination database: %w", err)
+	}
+	defer db.Close()
+
+	if err := ensureHistoryTable(db); err != nil {
+		return fmt.Errorf("failed to create %s: %w", historyTable, err)
+	}
+
+	m, err := migrate.New("file://"+migrationsDir, destCfg.URI())
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	defer m.Close()
+
+	switch op {
+	case "up":
+		return schemaUp(m, db)
+	case "down":
+		if len(opArgs) != 1 {
+			return fmt.Errorf("usage: schema down N")
+		}
+		n, err := strconv.Atoi(opArgs[0])
+		if err != nil {
+			return fmt.Errorf("invalid step count %q: %w", opArgs[0], err)
+		}
+		return schemaDown(m, db, n)
+	case "goto":
+		if len(opArgs) != 1 {
+			return fmt.Errorf("usage: schema goto V")
+		}
+		v, err := strconv.ParseUint(opArgs[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", opArgs[0], err)
+		}
+		return schemaGoto(m, db, uint(v))
+	case "force":
+		if len(opArgs) != 1 {
+			return fmt.Errorf("usage: schema force V")
+		}
+		v, err := strconv.Atoi(opArgs[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", opArgs[0], err)
+		}
+		log.Printf("⚠️ Forcing schema version to %d without running migrations...", v)
+		return m.Force(v)
+	case "version":
+		v, dirty, err := m.Version()
+		if err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		log.Printf("📌 Current schema version: %d (dirty=%v)", v, dirty)
+		return nil
+	default:
+		return fmt.Errorf("unknown schema operation %q (want up, down, goto, force, or version)", op)
+	}
+}
+
+// parseSchemaArgs parses the flags and positional operation for the `schema`
+// subcommand. It reuses the destination-side config flags/env vars/config
+// file resolution so source and destination stay configured consistently
+// across subcommands.
+func parseSchemaArgs(args []string) (dest DBConfig, migrationsDir string, op string, opArgs []string, err error) {
+	fs := flag.NewFlagSet("db-migration schema", flag.ExitOnError)
+
+	configPath := fs.String("config", "", "path to a YAML config file")
+	dir := fs.String("migrations-dir", "./migrations", "directory of golang-migrate schema migration files")
+
+	host := fs.String("dest-host", "", "destination database host")
+	port := fs.String("dest-port", "", "destination database port")
+	user := fs.String("dest-user", "", "destination database user")
+	password := fs.String("dest-password", "", "destination database password")
+	dbname := fs.String("dest-dbname", "", "destination database name")
+	sslMode := fs.String("dest-sslmode", "", "destination sslmode (disable/require/verify-ca/verify-full)")
+	sslRootCert := fs.String("dest-sslrootcert", "", "destination CA certificate path")
+	sslCert := fs.String("dest-sslcert", "", "destination client certificate path")
+	sslKey := fs.String("dest-sslkey", "", "destination client key path")
+
+	if err := fs.Parse(args); err != nil {
+		return DBConfig{}, "", "", nil, err
+	}
+
+	if *configPath != "" {
+		var cfg Config
+		if err := loadConfigFile(*configPath, &cfg); err != nil {
+			return DBConfig{}, "", "", nil, fmt.Errorf("failed to load config file %q: %w", *configPath, err)
+		}
+		dest = cfg.Destination
+	}
+
+	applyEnv(&dest, "MIGRATE_DEST")
+	applyFlags(&dest, host, port, user, password, dbname, sslMode, sslRootCert, sslCert, sslKey)
+
+	if dest.Host == "" || dest.DBName == "" {
+		return DBConfig{}, "", "", nil, fmt.Errorf("destination: host and dbname are required (via flags, MIGRATE_DEST_* env vars, or --config)")
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return DBConfig{}, "", "", nil, fmt.Errorf("usage: schema <up|down|goto|force|version> [arg]")
+	}
+
+	return dest, *dir, rest[0], rest[1:], nil
+}
+
+func ensureHistoryTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ` + historyTable + ` (
+			id          SERIAL PRIMARY KEY,
+			version     BIGINT NOT NULL,
+			direction   TEXT NOT NULL,
+			applied_at  TIMESTAMPTZ NOT NULL,
+			duration_ms BIGINT NOT NULL
+		)
+	`)
+	return err
+}
+
+func logHistory(db *sql.DB, version uint, direction string, appliedAt time.Time, duration time.Duration) {
+	_, err := db.Exec(
+		`INSERT INTO `+historyTable+` (version, direction, applied_at, duration_ms) VALUES ($1, $2, $3, $4)`,
+		version, direction, appliedAt, duration.Milliseconds(),
+	)
+	if err != nil {
+		log.Printf("⚠️ Failed to record %s of version %d in %s: %v", direction, version, historyTable, err)
+	}
+}
+
+// schemaUp applies all outstanding migrations one version at a time (via
+// repeated m.Steps(1) rather than m.Up()) so that every version gets its own
+// row in historyTable, even when several are outstanding.
+func schemaUp(m *migrate.Migrate, db *sql.DB) error {
+	log.Println("⬆️ Applying outstanding schema migrations...")
+	for {
+		start := time.Now()
+		err := m.Steps(1)
+		duration := time.Since(start)
+
+		if err == migrate.ErrNoChange {
+			log.Println("✅ Schema is already up to date.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply migration: %w", err)
+		}
+
+		version, _, verr := m.Version()
+		if verr == nil {
+			logHistory(db, version, "up", start, duration)
+			log.Printf("✅ Applied schema version %d in %s", version, duration)
+		}
+	}
+}
+
+// schemaDown reverts n versions one at a time, logging each to historyTable.
+func schemaDown(m *migrate.Migrate, db *sql.DB, n int) error {
+	log.Printf("⬇️ Reverting %d schema version(s)...", n)
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		err := m.Steps(-1)
+		duration := time.Since(start)
+
+		if err == migrate.ErrNoChange {
+			log.Println("✅ Nothing left to revert.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revert migration: %w", err)
+		}
+
+		version, _, verr := m.Version()
+		if verr == nil {
+			logHistory(db, version, "down", start, duration)
+			log.Printf("✅ Reverted to schema version %d in %s", version, duration)
+		}
+	}
+	return nil
+}
+
+// schemaGoto steps one version at a time toward target, in whichever
+// direction is needed, logging each step to historyTable.
+func schemaGoto(m *migrate.Migrate, db *sql.DB, target uint) error {
+	current, _, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return fmt.Errorf("failed to read current schema version: %w", err)
+	}
+
+	direction := 1
+	if current > target {
+		direction = -1
+	}
+
+	log.Printf("🎯 Moving schema from version %d to %d...", current, target)
+	for {
+		current, _, err = m.Version()
+		if err != nil && err != migrate.ErrNilVersion {
+			return fmt.Errorf("failed to read current schema version: %w", err)
+		}
+		if current == target {
+			log.Printf("✅ Schema is at version %d.", target)
+			return nil
+		}
+
+		start := time.Now()
+		err = m.Steps(direction)
+		duration := time.Since(start)
+		if err != nil {
+			return fmt.Errorf("failed to step schema version: %w", err)
+		}
+
+		version, _, verr := m.Version()
+		if verr == nil {
+			dirLabel := "up"
+			if direction < 0 {
+				dirLabel = "down"
+			}
+			logHistory(db, version, dirLabel, start, duration)
+			log.Printf("✅ Schema now at version %d in %s", version, duration)
+		}
+	}
+}
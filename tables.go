@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QualifiedTable identifies a table by schema and name. Tables are always
+// carried around schema-qualified so that lookups resolve correctly
+// regardless of search_path, and so that two tables with the same name in
+// different schemas aren't confused with each other.
+type QualifiedTable struct {
+	Schema string
+	Name   string
+}
+
+// String renders the table as "schema.name", used as the checkpoint key and
+// in pg_dump/pg_restore's --table argument.
+func (t QualifiedTable) String() string {
+	return t.Schema + "." + t.Name
+}
+
+// Ident renders the table as a quoted SQL identifier for use in queries.
+func (t QualifiedTable) Ident() string {
+	return fmt.Sprintf("%q.%q", t.Schema, t.Name)
+}
+
+// FileName returns a filesystem-safe name for this table's dump file.
+func (t QualifiedTable) FileName() string {
+	return t.Schema + "__" + t.Name + ".dump"
+}
+
+// TableStatus is the lifecycle state of a single table's migration.
+type TableStatus string
+
+const (
+	StatusPending TableStatus = "pending"
+	StatusDone    TableStatus = "done"
+	StatusFailed  TableStatus = "failed"
+)
+
+// TableCheckpoint records the progress of migrating a single table.
+type TableCheckpoint struct {
+	Table        string      `json:"table"`
+	Status       TableStatus `json:"status"`
+	RowsDumped   int64       `json:"rows_dumped"`
+	RowsRestored int64       `json:"rows_restored"`
+	StartedAt    *time.Time  `json:"started_at,omitempty"`
+	FinishedAt   *time.Time  `json:"finished_at,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// checkpointState is the on-disk JSON state file used to resume a per-table
+// migration that was interrupted.
+type checkpointState struct {
+	mu     sync.Mutex
+	path   string
+	Tables map[string]*TableCheckpoint `json:"tables"`
+}
+
+func loadCheckpointState(path string) (*checkpointState, error) {
+	state := &checkpointState{path: path, Tables: map[string]*TableCheckpoint{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %q: %w", path, err)
+	}
+	return state, nil
+}
+
+// save writes the checkpoint state to disk. Callers must hold s.mu.
+func (s *checkpointState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// resetCheckpointState discards dumpDir's checkpoint file so a subsequent
+// migrateTables call treats every table as pending again. Used after the
+// destination database has been dropped and recreated, since an on-disk
+// checkpoint marking tables "done" would otherwise make migrateTables skip
+// tables that no longer exist in the fresh destination.
+func resetCheckpointState(dumpDir string) error {
+	err := os.Remove(filepath.Join(dumpDir, "checkpoint.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *checkpointState) get(table QualifiedTable) *TableCheckpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := table.String()
+	cp, ok := s.Tables[key]
+	if !ok {
+		cp = &TableCheckpoint{Table: key, Status: StatusPending}
+		s.Tables[key] = cp
+	}
+	return cp
+}
+
+func (s *checkpointState) update(cp *TableCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tables[cp.Table] = cp
+	return s.save()
+}
+
+// updateTable mutates table's checkpoint via fn while holding s.mu, then
+// persists the result to disk. Concurrent migrateOneTable goroutines must
+// go through this rather than mutating a *TableCheckpoint they hold
+// directly -- save() marshals every checkpoint in the map, so a field
+// write on one table's checkpoint that isn't serialized against another
+// table's save() call is a data race.
+func (s *checkpointState) updateTable(table QualifiedTable, fn func(cp *TableCheckpoint)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := table.String()
+	cp, ok := s.Tables[key]
+	if !ok {
+		cp = &TableCheckpoint{Table: key, Status: StatusPending}
+		s.Tables[key] = cp
+	}
+	fn(cp)
+	return s.save()
+}
+
+// partitionByCheckpoint splits tables into those still needing migration and
+// those already marked done in state, so a resumed run only redoes
+// incomplete or never-started tables.
+func partitionByCheckpoint(tables []QualifiedTable, state *checkpointState) (toMigrate, alreadyDone []QualifiedTable) {
+	for _, table := range tables {
+		if state.get(table).Status == StatusDone {
+			alreadyDone = append(alreadyDone, table)
+		} else {
+			toMigrate = append(toMigrate, table)
+		}
+	}
+	return toMigrate, alreadyDone
+}
+
+// listTables enumerates user tables, ordered largest-first so the biggest
+// (longest-running) tables start dumping as early as possible.
+func listTables(db *sql.DB) ([]QualifiedTable, error) {
+	rows, err := db.Query(`
+		SELECT n.nspname, c.relname
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r'
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY pg_total_relation_size(c.oid) DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []QualifiedTable
+	for rows.Next() {
+		var t QualifiedTable
+		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// tableRowEstimate returns a fast, approximate row count for table using
+// pg_class.reltuples, falling back to an exact COUNT(*) if the estimate is
+// unavailable (e.g. the table was never analyzed). table is resolved via
+// ::regclass on its quoted, schema-qualified identifier so same-named
+// tables in other schemas, and mixed-case names, can't be mistaken for it.
+func tableRowEstimate(db *sql.DB, table QualifiedTable) (int64, error) {
+	var estimate float64
+	err := db.QueryRow(`SELECT reltuples FROM pg_class WHERE oid = $1::regclass`, table.Ident()).Scan(&estimate)
+	if err == nil && estimate > 0 {
+		return int64(estimate), nil
+	}
+
+	var count int64
+	if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table.Ident())).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// dumpTable writes a custom-format, data-only dump of a single table.
+func dumpTable(cfg *Config, dumpDir string, table QualifiedTable) error {
+	file := filepath.Join(dumpDir, table.FileName())
+	cmd := exec.Command("pg_dump",
+		"--format=custom", "--no-owner", "--no-acl", "--data-only",
+		"--table="+table.Ident(),
+		"--dbname="+cfg.Source.URI(),
+		"--file="+file,
+	)
+	return runCapturingStderr(cmd)
+}
+
+// restoreTable restores a single table's custom-format dump produced by
+// dumpTable.
+func restoreTable(cfg *Config, dumpDir string, table QualifiedTable) error {
+	file := filepath.Join(dumpDir, table.FileName())
+	cmd := exec.Command("pg_restore",
+		"--no-owner", "--no-acl", "--data-only",
+		"--dbname="+cfg.Destination.URI(),
+		file,
+	)
+	return runCapturingStderr(cmd)
+}
+
+// runCapturingStderr runs cmd with stdout/stderr streamed live as before,
+// but also tees stderr into a buffer so that, on failure, the returned
+// error includes the command's actual error output rather than just
+// "exit status 1" -- callers like isRecoverableRestoreError need to
+// inspect the message pg_dump/pg_restore printed.
+func runCapturingStderr(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+
+	err := cmd.Run()
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+// migrateTables enumerates tables from the source database and migrates
+// them one at a time (up to parallelTables concurrently), checkpointing
+// progress in dumpDir/checkpoint.json so an interrupted run can resume by
+// skipping tables already marked done.
+func migrateTables(cfg *Config, dumpDir string, parallelTables int) error {
+	sourceDB, err := sql.Open("postgres", cfg.Source.URI())
+	if err != nil {
+		return fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer sourceDB.Close()
+
+	tables, err := listTables(sourceDB)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate source tables: %w", err)
+	}
+	log.Printf("📋 Found %d table(s) to migrate.", len(tables))
+
+	state, err := loadCheckpointState(filepath.Join(dumpDir, "checkpoint.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint state: %w", err)
+	}
+
+	if parallelTables < 1 {
+		parallelTables = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, parallelTables)
+		mu       sync.Mutex
+		done     int
+		failures []string
+	)
+
+	toMigrate, alreadyDone := partitionByCheckpoint(tables, state)
+	done = len(alreadyDone)
+	for _, table := range alreadyDone {
+		log.Printf("⏭️  Skipping %s (already migrated).", table)
+	}
+
+	for _, table := range toMigrate {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(table QualifiedTable) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := migrateOneTable(cfg, sourceDB, dumpDir, table, state); err != nil {
+				mu.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", table, err))
+				mu.Unlock()
+				log.Printf("❌ %s failed: %v", table, err)
+				return
+			}
+
+			mu.Lock()
+			done++
+			log.Printf("✅ %s migrated (%d/%d tables done)", table, done, len(tables))
+			mu.Unlock()
+		}(table)
+	}
+
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d table(s) failed to migrate:\n%s", len(failures), joinLines(failures))
+	}
+	return nil
+}
+
+// migrateOneTable dumps, then restores, a single table, recording a
+// checkpoint before and after each phase.
+func migrateOneTable(cfg *Config, sourceDB *sql.DB, dumpDir string, table QualifiedTable, state *checkpointState) error {
+	now := time.Now()
+	if err := state.updateTable(table, func(cp *TableCheckpoint) {
+		cp.StartedAt = &now
+		cp.Status = StatusPending
+		cp.Error = ""
+	}); err != nil {
+		return err
+	}
+
+	rows, err := tableRowEstimate(sourceDB, table)
+	if err != nil {
+		log.Printf("⚠️ Could not estimate row count for %s: %v", table, err)
+	}
+
+	log.Printf("📦 Dumping %s (~%d rows)...", table, rows)
+	if err := dumpTable(cfg, dumpDir, table); err != nil {
+		return recordFailure(state, table, fmt.Errorf("dump failed: %w", err))
+	}
+	if err := state.updateTable(table, func(cp *TableCheckpoint) {
+		cp.RowsDumped = rows
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("🛠️ Restoring %s...", table)
+	if err := restoreTable(cfg, dumpDir, table); err != nil {
+		return recordFailure(state, table, fmt.Errorf("restore failed: %w", err))
+	}
+
+	finished := time.Now()
+	return state.updateTable(table, func(cp *TableCheckpoint) {
+		cp.RowsRestored = rows
+		cp.FinishedAt = &finished
+		cp.Status = StatusDone
+	})
+}
+
+func recordFailure(state *checkpointState, table QualifiedTable, err error) error {
+	finished := time.Now()
+	saveErr := state.updateTable(table, func(cp *TableCheckpoint) {
+		cp.Status = StatusFailed
+		cp.Error = err.Error()
+		cp.FinishedAt = &finished
+	})
+	if saveErr != nil {
+		log.Printf("⚠️ Failed to record checkpoint for %s: %v", table, saveErr)
+	}
+	return err
+}
+
+func joinLines(lines []string) string {
+	out := ""
+	for _, l := range lines {
+		out += "  - " + l + "\n"
+	}
+	return out
+}
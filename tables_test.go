@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPartitionByCheckpointSkipsDoneTables(t *testing.T) {
+	state, err := loadCheckpointState(filepath.Join(t.TempDir(), "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("loadCheckpointState: %v", err)
+	}
+
+	done := QualifiedTable{Schema: "public", Name: "users"}
+	pending := QualifiedTable{Schema: "public", Name: "orders"}
+	neverSeen := QualifiedTable{Schema: "public", Name: "items"}
+
+	state.get(done).Status = StatusDone
+	state.get(pending).Status = StatusFailed
+
+	toMigrate, alreadyDone := partitionByCheckpoint([]QualifiedTable{done, pending, neverSeen}, state)
+
+	if len(alreadyDone) != 1 || alreadyDone[0] != done {
+		t.Fatalf("alreadyDone = %v, want [%v]", alreadyDone, done)
+	}
+	if len(toMigrate) != 2 || toMigrate[0] != pending || toMigrate[1] != neverSeen {
+		t.Fatalf("toMigrate = %v, want [%v %v]", toMigrate, pending, neverSeen)
+	}
+}
+
+func TestCheckpointStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	state, err := loadCheckpointState(path)
+	if err != nil {
+		t.Fatalf("loadCheckpointState: %v", err)
+	}
+
+	table := QualifiedTable{Schema: "public", Name: "users"}
+	cp := state.get(table)
+	cp.Status = StatusDone
+	cp.RowsDumped = 42
+	cp.RowsRestored = 42
+	if err := state.update(cp); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	reloaded, err := loadCheckpointState(path)
+	if err != nil {
+		t.Fatalf("reload loadCheckpointState: %v", err)
+	}
+
+	got := reloaded.get(table)
+	if got.Status != StatusDone || got.RowsDumped != 42 || got.RowsRestored != 42 {
+		t.Fatalf("reloaded checkpoint = %+v, want status=done rows=42/42", got)
+	}
+}
+
+func TestResetCheckpointStateDiscardsProgress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+
+	state, err := loadCheckpointState(path)
+	if err != nil {
+		t.Fatalf("loadCheckpointState: %v", err)
+	}
+	table := QualifiedTable{Schema: "public", Name: "users"}
+	cp := state.get(table)
+	cp.Status = StatusDone
+	if err := state.update(cp); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if err := resetCheckpointState(dir); err != nil {
+		t.Fatalf("resetCheckpointState: %v", err)
+	}
+
+	reloaded, err := loadCheckpointState(path)
+	if err != nil {
+		t.Fatalf("reload loadCheckpointState: %v", err)
+	}
+	if reloaded.get(table).Status != StatusPending {
+		t.Fatalf("expected table to be pending again after reset, got %s", reloaded.get(table).Status)
+	}
+
+	// resetCheckpointState on an already-reset (missing) file must be a no-op, not an error.
+	if err := resetCheckpointState(dir); err != nil {
+		t.Fatalf("resetCheckpointState on missing file: %v", err)
+	}
+}